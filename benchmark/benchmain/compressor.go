@@ -0,0 +1,104 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"google.golang.org/grpc/encoding"
+)
+
+func init() {
+	encoding.RegisterCompressor(snappyCompressor{})
+	encoding.RegisterCompressor(zstdCompressor{})
+}
+
+// snappyCompressor implements encoding.Compressor for the Snappy format, so
+// that benchmarks can measure it alongside gzip via -compression=snappy.
+type snappyCompressor struct{}
+
+func (snappyCompressor) Name() string { return "snappy" }
+
+func (snappyCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return snappy.NewBufferedWriter(w), nil
+}
+
+func (snappyCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	return snappy.NewReader(r), nil
+}
+
+// zstdCompressor implements encoding.Compressor for Zstandard, so that
+// benchmarks can measure it alongside gzip and snappy via -compression=zstd.
+type zstdCompressor struct{}
+
+func (zstdCompressor) Name() string { return "zstd" }
+
+func (zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	return zw, nil
+}
+
+// zstdDecoderPool lets Decompress reuse *zstd.Decoder
+// goroutines/buffers across messages via Reset, instead of paying for a
+// fresh decoder (and its background goroutines) per message: encoding.
+// Compressor.Decompress returns a plain io.Reader, so the codec never has a
+// Close() hook to release a one-off decoder with, and a sustained
+// -compression=zstd run decompresses millions of messages.
+var zstdDecoderPool = sync.Pool{
+	New: func() interface{} {
+		zr, err := zstd.NewReader(nil)
+		if err != nil {
+			// Only fails on invalid options, and we pass none.
+			panic(err)
+		}
+		return zr
+	},
+}
+
+// pooledZstdReader returns its *zstd.Decoder to zstdDecoderPool once it's
+// been read to completion (or has errored), so the pool only ever holds
+// decoders that aren't in use.
+type pooledZstdReader struct {
+	dec *zstd.Decoder
+}
+
+func (z *pooledZstdReader) Read(p []byte) (int, error) {
+	n, err := z.dec.Read(p)
+	if err != nil {
+		zstdDecoderPool.Put(z.dec)
+		z.dec = nil
+	}
+	return n, err
+}
+
+func (zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	dec := zstdDecoderPool.Get().(*zstd.Decoder)
+	if err := dec.Reset(r); err != nil {
+		zstdDecoderPool.Put(dec)
+		return nil, err
+	}
+	return &pooledZstdReader{dec: dec}, nil
+}
@@ -0,0 +1,427 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+
+	"google.golang.org/grpc"
+	bm "google.golang.org/grpc/benchmark"
+	"google.golang.org/grpc/benchmark/benchmain/worker"
+	testpb "google.golang.org/grpc/benchmark/grpc_testing"
+	"google.golang.org/grpc/benchmark/stats"
+	"google.golang.org/grpc/grpclog"
+)
+
+// Modes that -mode accepts. modeLocal is the historical behavior where
+// benchmain runs client and server in the same process over bufconn or
+// localhost; the other three let benchmain take part in a distributed run
+// driven over the WorkerService protocol.
+const (
+	modeLocal  = "local"
+	modeDriver = "driver"
+	modeClient = "client"
+	modeServer = "server"
+)
+
+var allRunModes = []string{modeLocal, modeDriver, modeClient, modeServer}
+
+// runWorker starts a WorkerService server on workerPort and blocks until a
+// QuitWorker RPC is received. It backs both -mode=client and -mode=server;
+// the worker honors every WorkerService RPC, and whichever of StartServer or
+// StartClient the driver calls decides which side of the run it plays.
+func runWorker(workerPort int) {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", workerPort))
+	if err != nil {
+		grpclog.Fatalf("failed to listen on worker port %d: %v", workerPort, err)
+	}
+	s := grpc.NewServer()
+	quit := make(chan struct{})
+	worker.RegisterWorkerServiceServer(s, &workerServer{quit: quit})
+	go s.Serve(lis)
+	<-quit
+	s.Stop()
+}
+
+// workerServer implements worker.WorkerServiceServer, fulfilling either the
+// server side or the client side of a run depending on which Start* RPC the
+// driver issues.
+type workerServer struct {
+	quit chan struct{}
+
+	mu               sync.Mutex
+	histograms       []*hdrhistogram.Histogram
+	histMin, histMax int64
+	conn             *grpc.ClientConn
+	stopper          func()
+	lastMark         time.Time
+}
+
+func (w *workerServer) StartServer(ctx context.Context, cfg *worker.ServerConfig) (*worker.ServerStatus, error) {
+	port, stopper := startBenchmarkServer(cfg)
+	w.mu.Lock()
+	w.stopper = stopper
+	w.mu.Unlock()
+	return &worker.ServerStatus{Port: int32(port)}, nil
+}
+
+// startBenchmarkServer starts a benchmark server reachable from other
+// workers, listening on cfg.Port (0 picks any free port), and returns the
+// port it actually bound together with a stopper. Unlike makeClient (which
+// -mode=local uses to also dial a loopback client back to itself) this only
+// starts the server half: the client half of a distributed run lives on a
+// different worker.
+func startBenchmarkServer(cfg *worker.ServerConfig) (int, func()) {
+	sopts := append([]grpc.ServerOption{}, compressorServerOpts(cfg.Compression)...)
+	sopts = append(sopts, grpc.MaxConcurrentStreams(uint32(cfg.MaxConcurrentStreams)))
+	if serverCreds, _ := securityDialServerOpts(securityMode, certFile, keyFile); serverCreds != nil {
+		sopts = append(sopts, serverCreds)
+	}
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	if err != nil {
+		grpclog.Fatalf("failed to listen on server port %d: %v", cfg.Port, err)
+	}
+	stopper := bm.StartServer(bm.ServerInfo{Type: "protobuf", Listener: lis}, sopts...)
+	return lis.Addr().(*net.TCPAddr).Port, stopper
+}
+
+// StartClient dials the first of cfg.ServerTargets and spins up
+// cfg.OutstandingRpcsPerChannel worker goroutines against it, one outstanding
+// RPC each, the same concurrency model -mode=local uses for its closed-loop
+// benchmarks. cfg.LoadParams, when set to poisson/constant, switches each
+// goroutine to open-loop pacing sharing cfg.LoadParams.TargetQps; otherwise
+// each goroutine issues calls back-to-back.
+func (w *workerServer) StartClient(ctx context.Context, cfg *worker.ClientConfig) (*worker.ClientStatus, error) {
+	n := int(cfg.OutstandingRpcsPerChannel)
+	if n <= 0 {
+		n = 1
+	}
+	reqSize := int(cfg.PayloadConfig.ReqSizeBytes)
+	respSize := int(cfg.PayloadConfig.RespSizeBytes)
+
+	dialOpts := append([]grpc.DialOption{}, compressorDialOpts(cfg.Compression)...)
+	if _, clientCreds := securityDialServerOpts(securityMode, certFile, keyFile); clientCreds != nil {
+		dialOpts = append(dialOpts, clientCreds)
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+	conn := bm.NewClientConn(cfg.ServerTargets[0], dialOpts...)
+	tc := newBenchmarkClient(conn)
+
+	// call is built once up front the same way makeFuncUnary/makeFuncStream
+	// do for -mode=local, rather than branching on cfg.RpcType on every call.
+	var call func(pos int)
+	if cfg.RpcType == "streaming" {
+		streams := make([]testpb.BenchmarkService_StreamingCallClient, n)
+		for i := range streams {
+			stream, err := tc.StreamingCall(context.Background())
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			streams[i] = stream
+		}
+		call = func(pos int) { streamCaller(streams[pos], reqSize, respSize) }
+	} else {
+		call = func(int) { unaryCaller(tc, reqSize, respSize) }
+	}
+
+	loadType := loadClosed
+	var perWorkerQPS float64
+	if cfg.LoadParams != nil && cfg.LoadParams.LoadType != "" && cfg.LoadParams.LoadType != loadClosed {
+		loadType = cfg.LoadParams.LoadType
+		perWorkerQPS = cfg.LoadParams.TargetQps / float64(n)
+	}
+	histMin, histMax := histogramMinValue, histogramMaxValue
+	if cfg.HistogramParams != nil && cfg.HistogramParams.MaxPossible > 0 {
+		histMax = int64(cfg.HistogramParams.MaxPossible * float64(time.Second))
+	}
+
+	w.mu.Lock()
+	w.conn = conn
+	w.histMin, w.histMax = histMin, histMax
+	w.histograms = make([]*hdrhistogram.Histogram, n)
+	for i := range w.histograms {
+		w.histograms[i] = hdrhistogram.New(histMin, histMax, histogramSigFigs)
+	}
+	w.lastMark = time.Now()
+	w.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		go w.runClientLoop(i, call, loadType, perWorkerQPS)
+	}
+	return &worker.ClientStatus{}, nil
+}
+
+// runClientLoop drives one outstanding-RPC slot until QuitWorker closes
+// w.quit, recording each call's latency into its own histogram under w.mu,
+// since Mark merges and replaces the histograms while calls run. A closed-loop slot
+// issues calls back-to-back; an open-loop slot paces itself at targetQPS the
+// same way runOpenLoopBenchmark's pool does for -mode=local.
+func (w *workerServer) runClientLoop(pos int, call func(int), loadType string, targetQPS float64) {
+	next := time.Now()
+	for {
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+		if loadType != loadClosed {
+			var gap time.Duration
+			if loadType == loadPoisson {
+				gap = time.Duration(rand.ExpFloat64() / targetQPS * float64(time.Second))
+			} else {
+				gap = time.Duration(float64(time.Second) / targetQPS)
+			}
+			next = next.Add(gap)
+			time.Sleep(time.Until(next))
+		}
+		start := time.Now()
+		call(pos)
+		elapsed := time.Since(start)
+		w.mu.Lock()
+		w.histograms[pos].RecordValue(elapsed.Nanoseconds())
+		w.mu.Unlock()
+	}
+}
+
+func (w *workerServer) Mark(ctx context.Context, args *worker.MarkArgs) (*worker.Stats, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	elapsed := time.Since(w.lastMark)
+	if len(w.histograms) == 0 {
+		if args.Reset_ {
+			w.lastMark = time.Now()
+		}
+		return &worker.Stats{Seconds: elapsed.Seconds()}, nil
+	}
+	merged := mergeHistograms(w.histograms)
+	st := &worker.Stats{
+		Seconds:       elapsed.Seconds(),
+		Count:         merged.TotalCount(),
+		Latency_50Ms:  float64(merged.ValueAtQuantile(50)) / float64(time.Millisecond),
+		Latency_90Ms:  float64(merged.ValueAtQuantile(90)) / float64(time.Millisecond),
+		Latency_99Ms:  float64(merged.ValueAtQuantile(99)) / float64(time.Millisecond),
+		Latency_999Ms: float64(merged.ValueAtQuantile(99.9)) / float64(time.Millisecond),
+	}
+	if args.Reset_ {
+		for i := range w.histograms {
+			w.histograms[i] = hdrhistogram.New(w.histMin, w.histMax, histogramSigFigs)
+		}
+		w.lastMark = time.Now()
+	}
+	return st, nil
+}
+
+func (w *workerServer) QuitWorker(ctx context.Context, _ *worker.Void) (*worker.Void, error) {
+	w.mu.Lock()
+	if w.stopper != nil {
+		w.stopper()
+	}
+	if w.conn != nil {
+		w.conn.Close()
+	}
+	w.mu.Unlock()
+	close(w.quit)
+	return &worker.Void{}, nil
+}
+
+// newBenchmarkClient is a tiny indirection so StartClient above reads like
+// the rest of this file regardless of which BenchmarkService stub is wired
+// in; it just forwards to the generated client.
+func newBenchmarkClient(cc *grpc.ClientConn) testpb.BenchmarkServiceClient {
+	return testpb.NewBenchmarkServiceClient(cc)
+}
+
+// runDriver fans ServerConfig out to serverWorkers and ClientConfig out to
+// clientWorkers, polls Mark on the client workers once a second for
+// benchtime, and writes the aggregated result the same way the local
+// (non-distributed) path does via after().
+func runDriver(serverWorkers, clientWorkers []string, benchFeatures stats.Features, benchtime time.Duration) {
+	serverConns := dialWorkers(serverWorkers)
+	clientConns := dialWorkers(clientWorkers)
+	defer closeConns(append(serverConns, clientConns...))
+
+	var serverTargets []string
+	for i, cc := range serverConns {
+		c := worker.NewWorkerServiceClient(cc)
+		status, err := c.StartServer(context.Background(), &worker.ServerConfig{
+			ServerType:           "protobuf",
+			Compression:          benchFeatures.ModeCompressor,
+			MaxConcurrentStreams: int32(benchFeatures.MaxConcurrentCalls + 1),
+		})
+		if err != nil {
+			grpclog.Fatalf("driver: StartServer on %s failed: %v", serverWorkers[i], err)
+		}
+		serverTargets = append(serverTargets, fmt.Sprintf("%s:%d", hostOf(serverWorkers[i]), status.Port))
+	}
+
+	for i, cc := range clientConns {
+		c := worker.NewWorkerServiceClient(cc)
+		_, err := c.StartClient(context.Background(), &worker.ClientConfig{
+			ServerTargets: serverTargets,
+			RpcType:       "unary",
+			PayloadConfig: &worker.PayloadConfig{
+				ReqSizeBytes:  int32(benchFeatures.ReqSizeBytes),
+				RespSizeBytes: int32(benchFeatures.RespSizeBytes),
+			},
+			LoadParams:                &worker.LoadParams{LoadType: loadClosed},
+			HistogramParams:           &worker.HistogramParams{MaxPossible: float64(histogramMaxValue) / float64(time.Second)},
+			OutstandingRpcsPerChannel: int32(benchFeatures.MaxConcurrentCalls),
+			Compression:               benchFeatures.ModeCompressor,
+		})
+		if err != nil {
+			grpclog.Fatalf("driver: StartClient on %s failed: %v", clientWorkers[i], err)
+		}
+	}
+
+	deadline := time.Now().Add(benchtime)
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+		for i, cc := range clientConns {
+			c := worker.NewWorkerServiceClient(cc)
+			if _, err := c.Mark(context.Background(), &worker.MarkArgs{Reset_: false}); err != nil {
+				grpclog.Fatalf("driver: Mark on %s failed: %v", clientWorkers[i], err)
+			}
+		}
+	}
+
+	final := make([]*worker.Stats, len(clientConns))
+	for i, cc := range clientConns {
+		c := worker.NewWorkerServiceClient(cc)
+		st, err := c.Mark(context.Background(), &worker.MarkArgs{Reset_: false})
+		if err != nil {
+			grpclog.Fatalf("driver: Mark on %s failed: %v", clientWorkers[i], err)
+		}
+		final[i] = st
+	}
+
+	for _, cc := range append(serverConns, clientConns...) {
+		c := worker.NewWorkerServiceClient(cc)
+		c.QuitWorker(context.Background(), &worker.Void{})
+	}
+
+	writeDriverResult(benchFeatures, final, benchtime)
+
+	fmt.Printf("Distributed run across %d server worker(s) and %d client worker(s) complete\n",
+		len(serverConns), len(clientConns))
+}
+
+// writeDriverResult aggregates the per-client-worker Stats Mark reported into
+// a single row and writes it to -resultFile, the same way after() does for
+// -mode=local, so a distributed run feeds the same benchresult workflow.
+func writeDriverResult(benchFeatures stats.Features, results []*worker.Stats, benchtime time.Duration) {
+	if benchmarkResultFile == "" {
+		return
+	}
+	var total int64
+	var p50, p90, p99, p999 float64
+	for _, st := range results {
+		total += st.Count
+		p50 += st.Latency_50Ms * float64(st.Count)
+		p90 += st.Latency_90Ms * float64(st.Count)
+		p99 += st.Latency_99Ms * float64(st.Count)
+		p999 += st.Latency_999Ms * float64(st.Count)
+	}
+	if total > 0 {
+		p50 /= float64(total)
+		p90 /= float64(total)
+		p99 /= float64(total)
+		p999 /= float64(total)
+	}
+
+	if resultFormat == resultFormatJSON {
+		jr := jsonResult{
+			Name:     "Distributed",
+			Features: benchFeatures.String(),
+			Security: benchFeatures.Security,
+			Count:    total,
+			P50Us:    p50 * 1000,
+			P90Us:    p90 * 1000,
+			P99Us:    p99 * 1000,
+			P999Us:   p999 * 1000,
+			QPS:      float64(total) / benchtime.Seconds(),
+		}
+		if err := writeJSONResults(benchmarkResultFile, []jsonResult{jr}); err != nil {
+			grpclog.Fatalf("driver: can't write benchmark result %s: %v", benchmarkResultFile, err)
+		}
+		return
+	}
+
+	// gob format: feed a stats.Stats the per-worker percentiles Mark reported
+	// the same way -mode=local feeds it raw per-call latencies, since the raw
+	// samples themselves never leave the workers. The row this produces is
+	// structurally identical to -mode=local's, but its percentiles are an
+	// aggregate of each worker's aggregate rather than a true merge.
+	s := stats.NewStats(10)
+	for _, st := range results {
+		s.Add(time.Duration(st.Latency_50Ms * float64(time.Millisecond)))
+		s.Add(time.Duration(st.Latency_90Ms * float64(time.Millisecond)))
+		s.Add(time.Duration(st.Latency_99Ms * float64(time.Millisecond)))
+		s.Add(time.Duration(st.Latency_999Ms * float64(time.Millisecond)))
+	}
+	sharedPos := make([]bool, numSweepDimensions)
+	for i := range sharedPos {
+		sharedPos[i] = true
+	}
+	s.SetBenchmarkResult("Distributed", benchFeatures, int(total), 0, 0, sharedPos)
+	f, err := os.Create(benchmarkResultFile)
+	if err != nil {
+		grpclog.Fatalf("driver: can't write benchmark result %s: %v", benchmarkResultFile, err)
+	}
+	defer f.Close()
+	if err := gob.NewEncoder(f).Encode([]stats.BenchResults{s.GetBenchmarkResults()}); err != nil {
+		grpclog.Fatalf("driver: can't write benchmark result %s: %v", benchmarkResultFile, err)
+	}
+}
+
+func dialWorkers(addrs []string) []*grpc.ClientConn {
+	var conns []*grpc.ClientConn
+	for _, addr := range addrs {
+		cc, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(10*time.Second))
+		if err != nil {
+			grpclog.Fatalf("driver: failed to dial worker %s: %v", addr, err)
+		}
+		conns = append(conns, cc)
+	}
+	return conns
+}
+
+func closeConns(conns []*grpc.ClientConn) {
+	for _, cc := range conns {
+		cc.Close()
+	}
+}
+
+func hostOf(addr string) string {
+	if i := strings.LastIndex(addr, ":"); i != -1 {
+		return addr[:i]
+	}
+	return addr
+}
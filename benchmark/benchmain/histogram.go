@@ -0,0 +1,99 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+)
+
+const (
+	histogramMinValue = int64(time.Microsecond)
+	histogramMaxValue = int64(60 * time.Second)
+	histogramSigFigs  = 3
+)
+
+// newHistograms returns one HdrHistogram per worker goroutine so runBenchmark
+// can record latencies without any cross-goroutine locking; they are merged
+// into a single histogram once the run is done.
+func newHistograms(n int) []*hdrhistogram.Histogram {
+	hs := make([]*hdrhistogram.Histogram, n)
+	for i := range hs {
+		hs[i] = hdrhistogram.New(histogramMinValue, histogramMaxValue, histogramSigFigs)
+	}
+	return hs
+}
+
+func mergeHistograms(hs []*hdrhistogram.Histogram) *hdrhistogram.Histogram {
+	merged := hdrhistogram.New(histogramMinValue, histogramMaxValue, histogramSigFigs)
+	for _, h := range hs {
+		merged.Merge(h)
+	}
+	return merged
+}
+
+// jsonResult is the per-feature-permutation summary written when
+// -resultFormat=json is set, so `benchresult compare` has a stable,
+// language-agnostic format to diff against a threshold in CI.
+type jsonResult struct {
+	Name        string  `json:"name"`
+	Features    string  `json:"features"`
+	Security    string  `json:"security"`
+	Count       int64   `json:"count"`
+	P50Us       float64 `json:"p50_us"`
+	P90Us       float64 `json:"p90_us"`
+	P99Us       float64 `json:"p99_us"`
+	P999Us      float64 `json:"p999_us"`
+	MaxUs       float64 `json:"max_us"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+	QPS         float64 `json:"qps"`
+}
+
+func newJSONResult(name string, benchFeatures interface{ String() string }, h *hdrhistogram.Histogram, count uint64, allocsPerOp, bytesPerOp int64, benchtime time.Duration, security string) jsonResult {
+	toUs := func(ns int64) float64 { return float64(ns) / float64(time.Microsecond) }
+	return jsonResult{
+		Name:        name,
+		Features:    benchFeatures.String(),
+		Security:    security,
+		Count:       h.TotalCount(),
+		P50Us:       toUs(h.ValueAtQuantile(50)),
+		P90Us:       toUs(h.ValueAtQuantile(90)),
+		P99Us:       toUs(h.ValueAtQuantile(99)),
+		P999Us:      toUs(h.ValueAtQuantile(99.9)),
+		MaxUs:       toUs(h.Max()),
+		AllocsPerOp: allocsPerOp,
+		BytesPerOp:  bytesPerOp,
+		QPS:         float64(count) / benchtime.Seconds(),
+	}
+}
+
+func writeJSONResults(path string, results []jsonResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
@@ -0,0 +1,49 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import "testing"
+
+func TestMergeHistograms(t *testing.T) {
+	hs := newHistograms(3)
+	values := [][]int64{
+		{histogramMinValue * 10, histogramMinValue * 20},
+		{histogramMinValue * 30},
+		{},
+	}
+	var wantCount int64
+	for i, vs := range values {
+		for _, v := range vs {
+			hs[i].RecordValue(v)
+			wantCount++
+		}
+	}
+
+	merged := mergeHistograms(hs)
+	if got := merged.TotalCount(); got != wantCount {
+		t.Errorf("merged.TotalCount() = %d; want %d", got, wantCount)
+	}
+	// Max reports the largest value at the histogram's resolution, so compare
+	// against a histogram that recorded only the largest value.
+	ref := newHistograms(1)[0]
+	ref.RecordValue(histogramMinValue * 30)
+	if got, want := merged.Max(), ref.Max(); got != want {
+		t.Errorf("merged.Max() = %d; want %d", got, want)
+	}
+}
@@ -36,6 +36,12 @@ Assume there are two result files names as "basePerf" and "curPerf" created by a
   	go run benchmark/benchresult/main.go curPerf
 	To observe how the performance changes based on a base result, run:
   	go run benchmark/benchresult/main.go basePerf curPerf
+
+By default this binary runs client and server in the same process (-mode=local).
+Passing -mode=server or -mode=client instead turns it into a worker that speaks
+the WorkerService protocol and waits to be driven remotely; -mode=driver turns
+it into the driver that fans ServerConfig/ClientConfig out to -serverWorkers/
+-clientWorkers, polls their latency stats, and aggregates the result.
 */
 package main
 
@@ -48,6 +54,7 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"net"
 	"os"
 	"reflect"
@@ -60,6 +67,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/HdrHistogram/hdrhistogram-go"
+
 	"google.golang.org/grpc"
 	bm "google.golang.org/grpc/benchmark"
 	testpb "google.golang.org/grpc/benchmark/grpc_testing"
@@ -76,12 +85,14 @@ const (
 	modeBoth = "both"
 
 	// compression modes
-	modeAll  = "all"
-	modeGzip = "gzip"
-	modeNop  = "nop"
+	modeAll    = "all"
+	modeGzip   = "gzip"
+	modeNop    = "nop"
+	modeSnappy = "snappy"
+	modeZstd   = "zstd"
 )
 
-var allCompressionModes = []string{modeOff, modeGzip, modeNop, modeAll}
+var allCompressionModes = []string{modeOff, modeGzip, modeNop, modeSnappy, modeZstd, modeAll}
 var allTraceModes = []string{modeOn, modeOff, modeBoth}
 var allPreloaderModes = []string{modeOn, modeOff, modeBoth}
 
@@ -94,6 +105,36 @@ const (
 
 var allWorkloads = []string{workloadsUnary, workloadsStreaming, workloadsUnconstrained, workloadsAll}
 
+// result file formats
+const (
+	resultFormatGob  = "gob"
+	resultFormatJSON = "json"
+)
+
+// load generator modes
+const (
+	loadClosed   = "closed"
+	loadPoisson  = "poisson"
+	loadConstant = "constant"
+)
+
+var allLoadTypes = []string{loadClosed, loadPoisson, loadConstant}
+
+// numSweepDimensions is the length of the featuresPos/featuresNum/sharedPos
+// slices main() sweeps over (see the comment above featuresPos in main for
+// what each index is); writeDriverResult in driver.go builds its own
+// all-shared sharedPos off this same constant so it can't drift out of sync
+// with main's.
+const numSweepDimensions = 12
+
+// loadParams describes the open-loop schedule a benchmark run should follow;
+// it is kept separate from stats.Features since it controls how a run is
+// driven rather than a dimension of the workload itself.
+type loadParams struct {
+	loadType  string
+	targetQPS float64
+}
+
 var (
 	runMode = []bool{true, true, true} // {runUnary, runStream, runUnconstrained}
 	// When set the latency to 0 (no delay), the result is slower than the real result with no delay
@@ -113,6 +154,12 @@ var (
 	enableChannelz         []bool
 	networkMode            string
 	benchmarkResultFile    string
+	resultFormat           string
+	loadType               string
+	targetQPS              = []float64{0}
+	securityMode           string
+	securityModes          []string
+	certFile, keyFile      string
 	networks               = map[string]latency.Network{
 		"Local":    latency.Local,
 		"LAN":      latency.LAN,
@@ -121,16 +168,96 @@ var (
 	}
 )
 
-func unaryBenchmark(startTimer func(), stopTimer func(uint64), benchFeatures stats.Features, benchtime time.Duration, s *stats.Stats) uint64 {
+func unaryBenchmark(startTimer func(), stopTimer func(uint64), benchFeatures stats.Features, benchtime time.Duration, s *stats.Stats, lp loadParams) uint64 {
+	caller, cleanup := makeFuncUnary(benchFeatures)
+	defer cleanup()
+	if lp.loadType == loadClosed {
+		return runBenchmark(caller, startTimer, stopTimer, benchFeatures, benchtime, s)
+	}
+	count, _ := runOpenLoopBenchmark(caller, startTimer, stopTimer, benchFeatures, benchtime, s, lp)
+	return count
+}
+
+func streamBenchmark(startTimer func(), stopTimer func(uint64), benchFeatures stats.Features, benchtime time.Duration, s *stats.Stats, lp loadParams) uint64 {
+	caller, cleanup := makeFuncStream(benchFeatures)
+	defer cleanup()
+	if lp.loadType == loadClosed {
+		return runBenchmark(caller, startTimer, stopTimer, benchFeatures, benchtime, s)
+	}
+	count, _ := runOpenLoopBenchmark(caller, startTimer, stopTimer, benchFeatures, benchtime, s, lp)
+	return count
+}
+
+func unaryBenchmarkHistogram(startTimer func(), stopTimer func(uint64), benchFeatures stats.Features, benchtime time.Duration, s *stats.Stats, lp loadParams) (uint64, *hdrhistogram.Histogram) {
 	caller, cleanup := makeFuncUnary(benchFeatures)
 	defer cleanup()
-	return runBenchmark(caller, startTimer, stopTimer, benchFeatures, benchtime, s)
+	if lp.loadType == loadClosed {
+		return runBenchmarkWithHistogram(caller, startTimer, stopTimer, benchFeatures, benchtime, s)
+	}
+	return runOpenLoopBenchmark(caller, startTimer, stopTimer, benchFeatures, benchtime, s, lp)
 }
 
-func streamBenchmark(startTimer func(), stopTimer func(uint64), benchFeatures stats.Features, benchtime time.Duration, s *stats.Stats) uint64 {
+func streamBenchmarkHistogram(startTimer func(), stopTimer func(uint64), benchFeatures stats.Features, benchtime time.Duration, s *stats.Stats, lp loadParams) (uint64, *hdrhistogram.Histogram) {
 	caller, cleanup := makeFuncStream(benchFeatures)
 	defer cleanup()
-	return runBenchmark(caller, startTimer, stopTimer, benchFeatures, benchtime, s)
+	if lp.loadType == loadClosed {
+		return runBenchmarkWithHistogram(caller, startTimer, stopTimer, benchFeatures, benchtime, s)
+	}
+	return runOpenLoopBenchmark(caller, startTimer, stopTimer, benchFeatures, benchtime, s, lp)
+}
+
+// runOpenLoopBenchmark drives an open-loop (poisson or constant inter-arrival)
+// load: a scheduler goroutine computes each call's intended start time and
+// hands it to a fixed pool of MaxConcurrentCalls workers, without waiting for
+// prior calls to finish. The gap between intended and actual start is queueing
+// delay under load, and is folded into the recorded latency so percentiles
+// reflect coordinated omission honestly.
+func runOpenLoopBenchmark(caller func(int), startTimer func(), stopTimer func(uint64), benchFeatures stats.Features, benchtime time.Duration, s *stats.Stats, lp loadParams) (uint64, *hdrhistogram.Histogram) {
+	for i := 0; i < 10; i++ {
+		caller(0)
+	}
+	startTimer()
+
+	histograms := newHistograms(benchFeatures.MaxConcurrentCalls)
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		count uint64
+	)
+	jobs := make(chan time.Time, benchFeatures.MaxConcurrentCalls*4)
+	wg.Add(benchFeatures.MaxConcurrentCalls)
+	for i := 0; i < benchFeatures.MaxConcurrentCalls; i++ {
+		go func(pos int) {
+			defer wg.Done()
+			for intended := range jobs {
+				caller(pos)
+				elapse := time.Since(intended) // includes queueing delay, not just RPC latency
+				atomic.AddUint64(&count, 1)
+				histograms[pos].RecordValue(elapse.Nanoseconds())
+				mu.Lock()
+				s.Add(elapse)
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	bmEnd := time.Now().Add(benchtime)
+	next := time.Now()
+	for time.Now().Before(bmEnd) {
+		var gap time.Duration
+		if lp.loadType == loadPoisson {
+			gap = time.Duration(rand.ExpFloat64() / lp.targetQPS * float64(time.Second))
+		} else {
+			gap = time.Duration(float64(time.Second) / lp.targetQPS)
+		}
+		next = next.Add(gap)
+		time.Sleep(time.Until(next))
+		jobs <- next
+	}
+	close(jobs)
+	wg.Wait()
+	stopTimer(count)
+	return count, mergeHistograms(histograms)
 }
 
 func unconstrainedStreamBenchmark(benchFeatures stats.Features, warmuptime, benchtime time.Duration) (uint64, uint64) {
@@ -186,32 +313,57 @@ func unconstrainedStreamBenchmark(benchFeatures stats.Features, warmuptime, benc
 	return requestCount, responseCount
 }
 
-func makeClient(benchFeatures stats.Features) (testpb.BenchmarkServiceClient, func()) {
-	nw := &latency.Network{Kbps: benchFeatures.Kbps, Latency: benchFeatures.Latency, MTU: benchFeatures.Mtu}
-	opts := []grpc.DialOption{}
-	sopts := []grpc.ServerOption{}
-	if benchFeatures.ModeCompressor == "nop" {
-		sopts = append(sopts,
+// compressorServerOpts returns the grpc.ServerOption needed to enable mode on
+// the server side. nop/gzip use the legacy RPCCompressor API; snappy/zstd are
+// negotiated automatically through the encoding.Compressor registered in
+// compressor.go, so they need no server-side option.
+func compressorServerOpts(mode string) []grpc.ServerOption {
+	switch mode {
+	case "nop":
+		return []grpc.ServerOption{
 			grpc.RPCCompressor(nopCompressor{}),
 			grpc.RPCDecompressor(nopDecompressor{}),
-		)
-		opts = append(opts,
-			grpc.WithCompressor(nopCompressor{}),
-			grpc.WithDecompressor(nopDecompressor{}),
-		)
-	}
-	if benchFeatures.ModeCompressor == "gzip" {
-		sopts = append(sopts,
+		}
+	case "gzip":
+		return []grpc.ServerOption{
 			grpc.RPCCompressor(grpc.NewGZIPCompressor()),
 			grpc.RPCDecompressor(grpc.NewGZIPDecompressor()),
-		)
-		opts = append(opts,
+		}
+	default:
+		return nil
+	}
+}
+
+// compressorDialOpts is compressorServerOpts' client-side counterpart.
+func compressorDialOpts(mode string) []grpc.DialOption {
+	switch mode {
+	case "nop":
+		return []grpc.DialOption{
+			grpc.WithCompressor(nopCompressor{}),
+			grpc.WithDecompressor(nopDecompressor{}),
+		}
+	case "gzip":
+		return []grpc.DialOption{
 			grpc.WithCompressor(grpc.NewGZIPCompressor()),
 			grpc.WithDecompressor(grpc.NewGZIPDecompressor()),
-		)
+		}
+	case "snappy", "zstd":
+		return []grpc.DialOption{grpc.WithDefaultCallOptions(grpc.UseCompressor(mode))}
+	default:
+		return nil
 	}
+}
+
+func makeClient(benchFeatures stats.Features) (testpb.BenchmarkServiceClient, func()) {
+	nw := &latency.Network{Kbps: benchFeatures.Kbps, Latency: benchFeatures.Latency, MTU: benchFeatures.Mtu}
+	opts := append([]grpc.DialOption{}, compressorDialOpts(benchFeatures.ModeCompressor)...)
+	sopts := append([]grpc.ServerOption{}, compressorServerOpts(benchFeatures.ModeCompressor)...)
 	sopts = append(sopts, grpc.MaxConcurrentStreams(uint32(benchFeatures.MaxConcurrentCalls+1)))
-	opts = append(opts, grpc.WithInsecure())
+	serverCreds, clientCreds := securityDialServerOpts(benchFeatures.Security, certFile, keyFile)
+	if serverCreds != nil {
+		sopts = append(sopts, serverCreds)
+	}
+	opts = append(opts, clientCreds)
 
 	var lis net.Listener
 	if *useBufconn {
@@ -329,6 +481,15 @@ func streamCaller(stream testpb.BenchmarkService_StreamingCallClient, reqSize, r
 }
 
 func runBenchmark(caller func(int), startTimer func(), stopTimer func(uint64), benchFeatures stats.Features, benchtime time.Duration, s *stats.Stats) uint64 {
+	count, _ := runBenchmarkWithHistogram(caller, startTimer, stopTimer, benchFeatures, benchtime, s)
+	return count
+}
+
+// runBenchmarkWithHistogram is runBenchmark plus HdrHistogram recording: each
+// worker goroutine records into its own histogram (no locking), and the
+// histograms are merged once the run completes so -resultFormat=json can
+// report honest percentiles alongside the existing stats.Stats output.
+func runBenchmarkWithHistogram(caller func(int), startTimer func(), stopTimer func(uint64), benchFeatures stats.Features, benchtime time.Duration, s *stats.Stats) (uint64, *hdrhistogram.Histogram) {
 	// Warm up connection.
 	for i := 0; i < 10; i++ {
 		caller(0)
@@ -340,6 +501,7 @@ func runBenchmark(caller func(int), startTimer func(), stopTimer func(uint64), b
 		wg sync.WaitGroup
 	)
 	wg.Add(benchFeatures.MaxConcurrentCalls)
+	histograms := newHistograms(benchFeatures.MaxConcurrentCalls)
 	bmEnd := time.Now().Add(benchtime)
 	var count uint64
 	for i := 0; i < benchFeatures.MaxConcurrentCalls; i++ {
@@ -353,6 +515,7 @@ func runBenchmark(caller func(int), startTimer func(), stopTimer func(uint64), b
 				caller(pos)
 				elapse := time.Since(start)
 				atomic.AddUint64(&count, 1)
+				histograms[pos].RecordValue(elapse.Nanoseconds())
 				mu.Lock()
 				s.Add(elapse)
 				mu.Unlock()
@@ -362,11 +525,18 @@ func runBenchmark(caller func(int), startTimer func(), stopTimer func(uint64), b
 	}
 	wg.Wait()
 	stopTimer(count)
-	return count
+	return count, mergeHistograms(histograms)
 }
 
 var useBufconn = flag.Bool("bufconn", false, "Use in-memory connection instead of system network I/O")
 
+var (
+	runAsMode     string
+	workerPort    int
+	serverWorkers intSliceStringType
+	clientWorkers intSliceStringType
+)
+
 // Initiate main function to get settings of features.
 func init() {
 	var (
@@ -398,6 +568,21 @@ func init() {
 		fmt.Sprintf("Preloader mode - One of: %v", strings.Join(allPreloaderModes, ", ")))
 	flag.StringVar(&benchmarkResultFile, "resultFile", "", "Save the benchmark result into a binary file")
 	flag.StringVar(&networkMode, "networkMode", "", "Network mode includes LAN, WAN, Local and Longhaul")
+	flag.StringVar(&runAsMode, "mode", modeLocal,
+		fmt.Sprintf("Run mode - One of: %v", strings.Join(allRunModes, ", ")))
+	flag.IntVar(&workerPort, "workerPort", 10000, "Port this process listens on when -mode=client or -mode=server")
+	flag.Var(&serverWorkers, "serverWorkers", "host:workerPort list of server workers to drive (requires -mode=driver)")
+	flag.Var(&clientWorkers, "clientWorkers", "host:workerPort list of client workers to drive (requires -mode=driver)")
+	flag.StringVar(&resultFormat, "resultFormat", resultFormatGob,
+		fmt.Sprintf("Format of -resultFile - One of: %v", strings.Join([]string{resultFormatGob, resultFormatJSON}, ", ")))
+	var readTargetQPS floatSliceType
+	flag.StringVar(&loadType, "loadType", loadClosed,
+		fmt.Sprintf("Load generator mode - One of: %v", strings.Join(allLoadTypes, ", ")))
+	flag.Var(&readTargetQPS, "targetQPS", "Target aggregate QPS for -loadType=poisson or -loadType=constant - may be a comma-separated list")
+	flag.StringVar(&securityMode, "security", securityNone,
+		fmt.Sprintf("Transport security - One of: %v", strings.Join(allSecurityModes, ", ")))
+	flag.StringVar(&certFile, "certFile", "", "TLS cert file to use with -security=tls (a self-signed cert is generated if unset)")
+	flag.StringVar(&keyFile, "keyFile", "", "TLS key file to use with -security=tls (a self-signed cert is generated if unset)")
 	flag.Parse()
 	if flag.NArg() != 0 {
 		log.Fatal("Error: unparsed arguments: ", flag.Args())
@@ -424,6 +609,7 @@ func init() {
 			workloads, strings.Join(allWorkloads, ", "))
 	}
 	modeCompressor = setModeCompressor(compressorMode)
+	securityModes = setSecurityModes(securityMode)
 	enablePreloader = setMode(preloaderMode)
 	enableTrace = setMode(traceMode)
 	enableChannelz = setMode(channelzOn)
@@ -434,6 +620,14 @@ func init() {
 	readIntFromIntSlice(&maxConcurrentCalls, readMaxConcurrentCalls)
 	readIntFromIntSlice(&reqSizeBytes, readReqSizeBytes)
 	readIntFromIntSlice(&respSizeBytes, readRespSizeBytes)
+	readFloatFromFloatSlice(&targetQPS, readTargetQPS)
+	if loadType != loadClosed {
+		for _, q := range targetQPS {
+			if q <= 0 {
+				log.Fatalf("-targetQPS must be set to a positive value when -loadType=%s", loadType)
+			}
+		}
+	}
 	// Re-write latency, kpbs and mtu if network mode is set.
 	if network, ok := networks[networkMode]; ok {
 		ltc = []time.Duration{network.Latency}
@@ -457,14 +651,35 @@ func setMode(name string) []bool {
 	}
 }
 
+func setSecurityModes(name string) []string {
+	switch name {
+	case securityNone:
+		return []string{securityNone}
+	case securityTLS:
+		return []string{securityTLS}
+	case securityALTS:
+		return []string{securityALTS}
+	case securityAll:
+		return []string{securityNone, securityTLS, securityALTS}
+	default:
+		log.Fatalf("Unknown %s setting: %v (want one of: %v)",
+			name, name, strings.Join(allSecurityModes, ", "))
+		return []string{}
+	}
+}
+
 func setModeCompressor(name string) []string {
 	switch name {
 	case modeNop:
 		return []string{"nop"}
 	case modeGzip:
 		return []string{"gzip"}
+	case modeSnappy:
+		return []string{"snappy"}
+	case modeZstd:
+		return []string{"zstd"}
 	case modeAll:
-		return []string{"off", "nop", "gzip"}
+		return []string{"off", "nop", "gzip", "snappy", "zstd"}
 	case modeOff:
 		return []string{"off"}
 	default:
@@ -494,6 +709,45 @@ func (intSlice *intSliceType) Set(value string) error {
 	return nil
 }
 
+type floatSliceType []float64
+
+func (floatSlice *floatSliceType) String() string {
+	return fmt.Sprintf("%v", *floatSlice)
+}
+
+func (floatSlice *floatSliceType) Set(value string) error {
+	if len(*floatSlice) > 0 {
+		return errors.New("interval flag already set")
+	}
+	for _, num := range strings.Split(value, ",") {
+		next, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return err
+		}
+		*floatSlice = append(*floatSlice, next)
+	}
+	return nil
+}
+
+func readFloatFromFloatSlice(values *[]float64, replace floatSliceType) {
+	// If not set replace in the flag, just return to run the default settings.
+	if len(replace) == 0 {
+		return
+	}
+	*values = replace
+}
+
+// intSliceStringType parses a comma-separated list of worker addresses, the
+// same way intSliceType parses a comma-separated list of ints.
+type intSliceStringType []string
+
+func (s *intSliceStringType) String() string { return strings.Join(*s, ",") }
+
+func (s *intSliceStringType) Set(value string) error {
+	*s = strings.Split(value, ",")
+	return nil
+}
+
 func readIntFromIntSlice(values *[]int, replace intSliceType) {
 	// If not set replace in the flag, just return to run the default settings.
 	if len(replace) == 0 {
@@ -524,11 +778,28 @@ func printThroughput(requestCount uint64, requestSize int, responseCount uint64,
 }
 
 func main() {
+	switch runAsMode {
+	case modeClient, modeServer:
+		runWorker(workerPort)
+		return
+	case modeDriver:
+		runDriver(serverWorkers, clientWorkers, stats.Features{
+			ModeCompressor:     modeCompressor[0],
+			MaxConcurrentCalls: maxConcurrentCalls[0],
+			ReqSizeBytes:       reqSizeBytes[0],
+			RespSizeBytes:      respSizeBytes[0],
+			Security:           securityModes[0],
+		}, benchtime)
+		return
+	}
+
 	before()
-	featuresPos := make([]int, 10)
-	// 0:enableTracing 1:ltc 2:kbps 3:mtu 4:maxC 5:reqSize 6:respSize
+	featuresPos := make([]int, numSweepDimensions)
+	// 0:enableTracing 1:ltc 2:kbps 3:mtu 4:maxC 5:reqSize 6:respSize 7:modeCompressor
+	// 8:enableChannelz 9:enablePreloader 10:targetQPS 11:securityMode
 	featuresNum := []int{len(enableTrace), len(ltc), len(kbps), len(mtu),
-		len(maxConcurrentCalls), len(reqSizeBytes), len(respSizeBytes), len(modeCompressor), len(enableChannelz), len(enablePreloader)}
+		len(maxConcurrentCalls), len(reqSizeBytes), len(respSizeBytes), len(modeCompressor), len(enableChannelz), len(enablePreloader),
+		len(targetQPS), len(securityModes)}
 	initalPos := make([]int, len(featuresPos))
 	s := stats.NewStats(10)
 	s.SortLatency()
@@ -557,6 +828,7 @@ func main() {
 
 	// Run benchmarks
 	resultSlice := []stats.BenchResults{}
+	jsonResults := []jsonResult{}
 	for !reflect.DeepEqual(featuresPos, initalPos) || start {
 		start = false
 		benchFeature := stats.Features{
@@ -571,15 +843,26 @@ func main() {
 			ModeCompressor:     modeCompressor[featuresPos[7]],
 			EnableChannelz:     enableChannelz[featuresPos[8]],
 			EnablePreloader:    enablePreloader[featuresPos[9]],
+			Security:           securityModes[featuresPos[11]],
 		}
+		lp := loadParams{loadType: loadType, targetQPS: targetQPS[featuresPos[10]]}
+		unaryName, streamName, unconstrainedName := "Unary", "Stream", "Unconstrained Stream"
 
 		grpc.EnableTracing = enableTrace[featuresPos[0]]
 		if enableChannelz[featuresPos[8]] {
 			channelz.TurnOn()
 		}
 		if runMode[0] {
-			count := unaryBenchmark(startTimer, stopTimer, benchFeature, benchtime, s)
-			s.SetBenchmarkResult("Unary", benchFeature, results.N,
+			var count uint64
+			if resultFormat == resultFormatJSON {
+				var h *hdrhistogram.Histogram
+				count, h = unaryBenchmarkHistogram(startTimer, stopTimer, benchFeature, benchtime, s, lp)
+				jsonResults = append(jsonResults, newJSONResult(unaryName, benchFeature, h, count,
+					results.AllocsPerOp(), results.AllocedBytesPerOp(), benchtime, benchFeature.Security))
+			} else {
+				count = unaryBenchmark(startTimer, stopTimer, benchFeature, benchtime, s, lp)
+			}
+			s.SetBenchmarkResult(unaryName, benchFeature, results.N,
 				results.AllocedBytesPerOp(), results.AllocsPerOp(), sharedPos)
 			fmt.Println(s.BenchString())
 			fmt.Println(s.String())
@@ -588,8 +871,16 @@ func main() {
 			s.Clear()
 		}
 		if runMode[1] {
-			count := streamBenchmark(startTimer, stopTimer, benchFeature, benchtime, s)
-			s.SetBenchmarkResult("Stream", benchFeature, results.N,
+			var count uint64
+			if resultFormat == resultFormatJSON {
+				var h *hdrhistogram.Histogram
+				count, h = streamBenchmarkHistogram(startTimer, stopTimer, benchFeature, benchtime, s, lp)
+				jsonResults = append(jsonResults, newJSONResult(streamName, benchFeature, h, count,
+					results.AllocsPerOp(), results.AllocedBytesPerOp(), benchtime, benchFeature.Security))
+			} else {
+				count = streamBenchmark(startTimer, stopTimer, benchFeature, benchtime, s, lp)
+			}
+			s.SetBenchmarkResult(streamName, benchFeature, results.N,
 				results.AllocedBytesPerOp(), results.AllocsPerOp(), sharedPos)
 			fmt.Println(s.BenchString())
 			fmt.Println(s.String())
@@ -599,12 +890,12 @@ func main() {
 		}
 		if runMode[2] {
 			requestCount, responseCount := unconstrainedStreamBenchmark(benchFeature, time.Second, benchtime)
-			fmt.Printf("Unconstrained Stream-%v\n", benchFeature)
+			fmt.Printf("%s-%v\n", unconstrainedName, benchFeature)
 			printThroughput(requestCount, benchFeature.ReqSizeBytes, responseCount, benchFeature.RespSizeBytes)
 		}
 		bm.AddOne(featuresPos, featuresNum)
 	}
-	after(resultSlice)
+	after(resultSlice, jsonResults)
 }
 
 func before() {
@@ -625,7 +916,7 @@ func before() {
 	}
 }
 
-func after(data []stats.BenchResults) {
+func after(data []stats.BenchResults, jsonData []jsonResult) {
 	if cpuProfile != "" {
 		pprof.StopCPUProfile() // flushes profile to disk
 	}
@@ -642,15 +933,22 @@ func after(data []stats.BenchResults) {
 		}
 		f.Close()
 	}
-	if benchmarkResultFile != "" {
-		f, err := os.Create(benchmarkResultFile)
-		if err != nil {
+	if benchmarkResultFile == "" {
+		return
+	}
+	if resultFormat == resultFormatJSON {
+		if err := writeJSONResults(benchmarkResultFile, jsonData); err != nil {
 			log.Fatalf("testing: can't write benchmark result %s: %s\n", benchmarkResultFile, err)
 		}
-		dataEncoder := gob.NewEncoder(f)
-		dataEncoder.Encode(data)
-		f.Close()
+		return
+	}
+	f, err := os.Create(benchmarkResultFile)
+	if err != nil {
+		log.Fatalf("testing: can't write benchmark result %s: %s\n", benchmarkResultFile, err)
 	}
+	dataEncoder := gob.NewEncoder(f)
+	dataEncoder.Encode(data)
+	f.Close()
 }
 
 // nopCompressor is a compressor that just copies data.
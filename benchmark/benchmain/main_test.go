@@ -0,0 +1,60 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetModeCompressor(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{modeNop, []string{"nop"}},
+		{modeGzip, []string{"gzip"}},
+		{modeSnappy, []string{"snappy"}},
+		{modeZstd, []string{"zstd"}},
+		{modeOff, []string{"off"}},
+		{modeAll, []string{"off", "nop", "gzip", "snappy", "zstd"}},
+	}
+	for _, tt := range tests {
+		if got := setModeCompressor(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("setModeCompressor(%q) = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetSecurityModes(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{securityNone, []string{securityNone}},
+		{securityTLS, []string{securityTLS}},
+		{securityALTS, []string{securityALTS}},
+		{securityAll, []string{securityNone, securityTLS, securityALTS}},
+	}
+	for _, tt := range tests {
+		if got := setSecurityModes(tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("setSecurityModes(%q) = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
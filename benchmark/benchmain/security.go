@@ -0,0 +1,113 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/alts"
+	"google.golang.org/grpc/grpclog"
+)
+
+// security modes for the -security flag. securityAll sweeps all three, the
+// same way modeAll does for -compression.
+const (
+	securityNone = "none"
+	securityTLS  = "tls"
+	securityALTS = "alts"
+	securityAll  = "all"
+)
+
+var allSecurityModes = []string{securityNone, securityTLS, securityALTS, securityAll}
+
+var selfSignedCertOnce sync.Once
+var selfSignedCert tls.Certificate
+
+// selfSignedTLSCert lazily generates an in-memory self-signed certificate for
+// -security=tls runs where -certFile/-keyFile were not provided, so the
+// harness can measure TLS overhead without requiring the user to hand it one.
+func selfSignedTLSCert() tls.Certificate {
+	selfSignedCertOnce.Do(func() {
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			grpclog.Fatalf("failed to generate self-signed key: %v", err)
+		}
+		template := x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(24 * time.Hour),
+			KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			DNSNames:     []string{"localhost"},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+		if err != nil {
+			grpclog.Fatalf("failed to create self-signed certificate: %v", err)
+		}
+		certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			grpclog.Fatalf("failed to load self-signed certificate: %v", err)
+		}
+		selfSignedCert = cert
+	})
+	return selfSignedCert
+}
+
+// securityDialServerOpts returns the grpc.ServerOption/grpc.DialOption pair
+// needed to run a benchmark server/client pair under the given -security
+// mode, for symmetrically augmenting sopts and opts the same way
+// compressorServerOpts/compressorDialOpts in main.go do for the compression
+// modes. For -security=none the ServerOption is nil and the DialOption is
+// grpc.WithInsecure().
+func securityDialServerOpts(mode, certFile, keyFile string) (grpc.ServerOption, grpc.DialOption) {
+	switch mode {
+	case securityTLS:
+		var cert tls.Certificate
+		if certFile != "" && keyFile != "" {
+			var err error
+			cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				grpclog.Fatalf("failed to load -certFile/-keyFile: %v", err)
+			}
+		} else {
+			cert = selfSignedTLSCert()
+		}
+		serverCreds := credentials.NewServerTLSFromCert(&cert)
+		clientCreds := credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}, InsecureSkipVerify: true})
+		return grpc.Creds(serverCreds), grpc.WithTransportCredentials(clientCreds)
+	case securityALTS:
+		return grpc.Creds(alts.NewServerCreds(&alts.ServerOptions{})),
+			grpc.WithTransportCredentials(alts.NewClientCreds(&alts.ClientOptions{}))
+	default:
+		return nil, grpc.WithInsecure()
+	}
+}
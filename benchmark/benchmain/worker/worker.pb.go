@@ -0,0 +1,247 @@
+// Package worker holds the Go types and gRPC client/server stubs for the
+// WorkerService defined in worker.proto. It is hand-written to match what
+// protoc-gen-go/protoc-gen-go-grpc would emit, since this tree doesn't vendor
+// protoc; regenerate it with protoc/protoc-gen-go/protoc-gen-go-grpc from
+// worker.proto instead of hand-editing once those are available, and delete
+// this file's hand-maintained copies of the message-set machinery below.
+package worker
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+
+type Void struct{}
+
+func (m *Void) Reset()         { *m = Void{} }
+func (m *Void) String() string { return proto.CompactTextString(m) }
+func (*Void) ProtoMessage()    {}
+
+type ServerConfig struct {
+	ServerType           string `protobuf:"bytes,1,opt,name=server_type,json=serverType,proto3" json:"server_type,omitempty"`
+	Port                 int32  `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	Compression          string `protobuf:"bytes,3,opt,name=compression,proto3" json:"compression,omitempty"`
+	MaxConcurrentStreams int32  `protobuf:"varint,4,opt,name=max_concurrent_streams,json=maxConcurrentStreams,proto3" json:"max_concurrent_streams,omitempty"`
+	EnableChannelz       bool   `protobuf:"varint,5,opt,name=enable_channelz,json=enableChannelz,proto3" json:"enable_channelz,omitempty"`
+}
+
+func (m *ServerConfig) Reset()         { *m = ServerConfig{} }
+func (m *ServerConfig) String() string { return proto.CompactTextString(m) }
+func (*ServerConfig) ProtoMessage()    {}
+
+type ServerStatus struct {
+	Port  int32 `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+	Cores int32 `protobuf:"varint,2,opt,name=cores,proto3" json:"cores,omitempty"`
+}
+
+func (m *ServerStatus) Reset()         { *m = ServerStatus{} }
+func (m *ServerStatus) String() string { return proto.CompactTextString(m) }
+func (*ServerStatus) ProtoMessage()    {}
+
+type PayloadConfig struct {
+	ReqSizeBytes  int32 `protobuf:"varint,1,opt,name=req_size_bytes,json=reqSizeBytes,proto3" json:"req_size_bytes,omitempty"`
+	RespSizeBytes int32 `protobuf:"varint,2,opt,name=resp_size_bytes,json=respSizeBytes,proto3" json:"resp_size_bytes,omitempty"`
+}
+
+func (m *PayloadConfig) Reset()         { *m = PayloadConfig{} }
+func (m *PayloadConfig) String() string { return proto.CompactTextString(m) }
+func (*PayloadConfig) ProtoMessage()    {}
+
+type LoadParams struct {
+	LoadType   string  `protobuf:"bytes,1,opt,name=load_type,json=loadType,proto3" json:"load_type,omitempty"`
+	TargetQps  float64 `protobuf:"fixed64,2,opt,name=target_qps,json=targetQps,proto3" json:"target_qps,omitempty"`
+}
+
+func (m *LoadParams) Reset()         { *m = LoadParams{} }
+func (m *LoadParams) String() string { return proto.CompactTextString(m) }
+func (*LoadParams) ProtoMessage()    {}
+
+type HistogramParams struct {
+	Resolution  float64 `protobuf:"fixed64,1,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	MaxPossible float64 `protobuf:"fixed64,2,opt,name=max_possible,json=maxPossible,proto3" json:"max_possible,omitempty"`
+}
+
+func (m *HistogramParams) Reset()         { *m = HistogramParams{} }
+func (m *HistogramParams) String() string { return proto.CompactTextString(m) }
+func (*HistogramParams) ProtoMessage()    {}
+
+type ClientConfig struct {
+	ServerTargets             []string         `protobuf:"bytes,1,rep,name=server_targets,json=serverTargets,proto3" json:"server_targets,omitempty"`
+	RpcType                   string           `protobuf:"bytes,2,opt,name=rpc_type,json=rpcType,proto3" json:"rpc_type,omitempty"`
+	PayloadConfig             *PayloadConfig   `protobuf:"bytes,3,opt,name=payload_config,json=payloadConfig,proto3" json:"payload_config,omitempty"`
+	LoadParams                *LoadParams      `protobuf:"bytes,4,opt,name=load_params,json=loadParams,proto3" json:"load_params,omitempty"`
+	HistogramParams           *HistogramParams `protobuf:"bytes,5,opt,name=histogram_params,json=histogramParams,proto3" json:"histogram_params,omitempty"`
+	ClientChannels            int32            `protobuf:"varint,6,opt,name=client_channels,json=clientChannels,proto3" json:"client_channels,omitempty"`
+	OutstandingRpcsPerChannel int32            `protobuf:"varint,7,opt,name=outstanding_rpcs_per_channel,json=outstandingRpcsPerChannel,proto3" json:"outstanding_rpcs_per_channel,omitempty"`
+	Compression               string           `protobuf:"bytes,8,opt,name=compression,proto3" json:"compression,omitempty"`
+}
+
+func (m *ClientConfig) Reset()         { *m = ClientConfig{} }
+func (m *ClientConfig) String() string { return proto.CompactTextString(m) }
+func (*ClientConfig) ProtoMessage()    {}
+
+type ClientStatus struct{}
+
+func (m *ClientStatus) Reset()         { *m = ClientStatus{} }
+func (m *ClientStatus) String() string { return proto.CompactTextString(m) }
+func (*ClientStatus) ProtoMessage()    {}
+
+type MarkArgs struct {
+	Reset_ bool `protobuf:"varint,1,opt,name=reset,proto3" json:"reset,omitempty"`
+}
+
+func (m *MarkArgs) Reset()         { *m = MarkArgs{} }
+func (m *MarkArgs) String() string { return proto.CompactTextString(m) }
+func (*MarkArgs) ProtoMessage()    {}
+
+type Stats struct {
+	Latency_50Ms  float64 `protobuf:"fixed64,1,opt,name=latency_50_ms,json=latency50Ms,proto3" json:"latency_50_ms,omitempty"`
+	Latency_90Ms  float64 `protobuf:"fixed64,2,opt,name=latency_90_ms,json=latency90Ms,proto3" json:"latency_90_ms,omitempty"`
+	Latency_99Ms  float64 `protobuf:"fixed64,3,opt,name=latency_99_ms,json=latency99Ms,proto3" json:"latency_99_ms,omitempty"`
+	Latency_999Ms float64 `protobuf:"fixed64,4,opt,name=latency_999_ms,json=latency999Ms,proto3" json:"latency_999_ms,omitempty"`
+	Count         int64   `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	Seconds       float64 `protobuf:"fixed64,6,opt,name=seconds,proto3" json:"seconds,omitempty"`
+}
+
+func (m *Stats) Reset()         { *m = Stats{} }
+func (m *Stats) String() string { return proto.CompactTextString(m) }
+func (*Stats) ProtoMessage()    {}
+
+// WorkerServiceClient is the client API for WorkerService service.
+type WorkerServiceClient interface {
+	StartServer(ctx context.Context, in *ServerConfig, opts ...grpc.CallOption) (*ServerStatus, error)
+	StartClient(ctx context.Context, in *ClientConfig, opts ...grpc.CallOption) (*ClientStatus, error)
+	Mark(ctx context.Context, in *MarkArgs, opts ...grpc.CallOption) (*Stats, error)
+	QuitWorker(ctx context.Context, in *Void, opts ...grpc.CallOption) (*Void, error)
+}
+
+type workerServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewWorkerServiceClient returns a client for the WorkerService defined in
+// worker.proto.
+func NewWorkerServiceClient(cc *grpc.ClientConn) WorkerServiceClient {
+	return &workerServiceClient{cc}
+}
+
+func (c *workerServiceClient) StartServer(ctx context.Context, in *ServerConfig, opts ...grpc.CallOption) (*ServerStatus, error) {
+	out := new(ServerStatus)
+	if err := c.cc.Invoke(ctx, "/grpc.benchmark.worker.WorkerService/StartServer", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workerServiceClient) StartClient(ctx context.Context, in *ClientConfig, opts ...grpc.CallOption) (*ClientStatus, error) {
+	out := new(ClientStatus)
+	if err := c.cc.Invoke(ctx, "/grpc.benchmark.worker.WorkerService/StartClient", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workerServiceClient) Mark(ctx context.Context, in *MarkArgs, opts ...grpc.CallOption) (*Stats, error) {
+	out := new(Stats)
+	if err := c.cc.Invoke(ctx, "/grpc.benchmark.worker.WorkerService/Mark", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workerServiceClient) QuitWorker(ctx context.Context, in *Void, opts ...grpc.CallOption) (*Void, error) {
+	out := new(Void)
+	if err := c.cc.Invoke(ctx, "/grpc.benchmark.worker.WorkerService/QuitWorker", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WorkerServiceServer is the server API for WorkerService service.
+type WorkerServiceServer interface {
+	StartServer(context.Context, *ServerConfig) (*ServerStatus, error)
+	StartClient(context.Context, *ClientConfig) (*ClientStatus, error)
+	Mark(context.Context, *MarkArgs) (*Stats, error)
+	QuitWorker(context.Context, *Void) (*Void, error)
+}
+
+func RegisterWorkerServiceServer(s *grpc.Server, srv WorkerServiceServer) {
+	s.RegisterService(&_WorkerService_serviceDesc, srv)
+}
+
+func _WorkerService_StartServer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ServerConfig)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkerServiceServer).StartServer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/grpc.benchmark.worker.WorkerService/StartServer"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkerServiceServer).StartServer(ctx, req.(*ServerConfig))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorkerService_StartClient_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientConfig)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkerServiceServer).StartClient(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/grpc.benchmark.worker.WorkerService/StartClient"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkerServiceServer).StartClient(ctx, req.(*ClientConfig))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorkerService_Mark_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkArgs)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkerServiceServer).Mark(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/grpc.benchmark.worker.WorkerService/Mark"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkerServiceServer).Mark(ctx, req.(*MarkArgs))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WorkerService_QuitWorker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Void)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkerServiceServer).QuitWorker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/grpc.benchmark.worker.WorkerService/QuitWorker"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkerServiceServer).QuitWorker(ctx, req.(*Void))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _WorkerService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "grpc.benchmark.worker.WorkerService",
+	HandlerType: (*WorkerServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{
+		{MethodName: "StartServer", Handler: _WorkerService_StartServer_Handler},
+		{MethodName: "StartClient", Handler: _WorkerService_StartClient_Handler},
+		{MethodName: "Mark", Handler: _WorkerService_Mark_Handler},
+		{MethodName: "QuitWorker", Handler: _WorkerService_QuitWorker_Handler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "worker.proto",
+}
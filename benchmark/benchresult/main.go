@@ -0,0 +1,197 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+/*
+Package main formats benchmark results produced by benchmain.
+
+Given one -resultFile from benchmain, it prints that result:
+	go run benchmark/benchresult/main.go curPerf
+
+Given two, it prints how curPerf changed relative to basePerf:
+	go run benchmark/benchresult/main.go basePerf curPerf
+
+Given -resultFormat=json result files (see benchmain's -resultFormat flag),
+the "compare" subcommand instead exits non-zero when any latency percentile
+in curPerf regressed beyond -threshold relative to basePerf, so it can gate a
+PR in CI:
+	go run benchmark/benchresult/main.go compare -threshold=5% basePerf curPerf
+*/
+package main
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/benchmark/stats"
+)
+
+// jsonResult mirrors the type benchmain writes under -resultFormat=json; it
+// is duplicated here (rather than imported) because the two binaries don't
+// otherwise share a package.
+type jsonResult struct {
+	Name        string  `json:"name"`
+	Features    string  `json:"features"`
+	Security    string  `json:"security"`
+	Count       int64   `json:"count"`
+	P50Us       float64 `json:"p50_us"`
+	P90Us       float64 `json:"p90_us"`
+	P99Us       float64 `json:"p99_us"`
+	P999Us      float64 `json:"p999_us"`
+	MaxUs       float64 `json:"max_us"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+	QPS         float64 `json:"qps"`
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		compareMain(os.Args[2:])
+		return
+	}
+
+	flag.Parse()
+	if flag.NArg() > 2 || flag.NArg() < 1 {
+		log.Fatalf("Please provide either one benchmark result file to print, " +
+			"or two to compare, as command line parameters")
+	}
+
+	before := make([]stats.BenchResults, 0)
+	readFile(flag.Arg(0), &before)
+
+	var after []stats.BenchResults
+	if flag.NArg() == 2 {
+		after = make([]stats.BenchResults, 0)
+		readFile(flag.Arg(1), &after)
+	}
+
+	if after == nil {
+		for _, b := range before {
+			fmt.Println(b.String())
+		}
+		return
+	}
+
+	if len(before) != len(after) {
+		log.Fatalf("result sets have different length (%d vs %d); can't compare a run "+
+			"against a different set of feature permutations", len(before), len(after))
+	}
+	for i := range before {
+		fmt.Println(after[i].DiffString(before[i]))
+	}
+}
+
+func readFile(path string, data interface{}) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := gob.NewDecoder(f).Decode(data); err != nil {
+		log.Fatalf("Failed to decode %s: %v", path, err)
+	}
+}
+
+// compareMain implements `benchresult compare -threshold=5% base.json cur.json`.
+func compareMain(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	threshold := fs.String("threshold", "5%", "Maximum allowed percentile regression, e.g. 5% or 0.05")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		log.Fatalf("compare takes exactly two -resultFormat=json result files: base and current")
+	}
+	thresholdRatio, err := parseThreshold(*threshold)
+	if err != nil {
+		log.Fatalf("invalid -threshold %q: %v", *threshold, err)
+	}
+
+	var base, cur []jsonResult
+	readJSONFile(fs.Arg(0), &base)
+	readJSONFile(fs.Arg(1), &cur)
+	if len(base) != len(cur) {
+		log.Fatalf("result sets have different length (%d vs %d); can't compare a run "+
+			"against a different set of feature permutations", len(base), len(cur))
+	}
+
+	regressed := false
+	for i := range base {
+		for _, p := range []struct {
+			name   string
+			baseUs float64
+			curUs  float64
+		}{
+			{"p50", base[i].P50Us, cur[i].P50Us},
+			{"p90", base[i].P90Us, cur[i].P90Us},
+			{"p99", base[i].P99Us, cur[i].P99Us},
+			{"p99.9", base[i].P999Us, cur[i].P999Us},
+		} {
+			delta, isRegressed := isRegression(p.baseUs, p.curUs, thresholdRatio)
+			if isRegressed {
+				regressed = true
+				fmt.Printf("REGRESSION: %s %s %s: %.1fus -> %.1fus (+%.1f%%, threshold %.1f%%)\n",
+					cur[i].Name, cur[i].Features, p.name, p.baseUs, p.curUs, delta*100, thresholdRatio*100)
+			}
+		}
+	}
+	if regressed {
+		os.Exit(1)
+	}
+	fmt.Println("No regressions beyond threshold")
+}
+
+func readJSONFile(path string, data interface{}) {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(data); err != nil {
+		log.Fatalf("Failed to decode %s: %v", path, err)
+	}
+}
+
+// isRegression reports whether curUs regressed beyond thresholdRatio of
+// baseUs, along with the (possibly negative) delta ratio; a non-positive
+// baseUs (no base sample for this percentile) never counts as a regression.
+func isRegression(baseUs, curUs, thresholdRatio float64) (delta float64, regressed bool) {
+	if baseUs <= 0 {
+		return 0, false
+	}
+	delta = (curUs - baseUs) / baseUs
+	return delta, delta > thresholdRatio
+}
+
+func parseThreshold(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	pct := strings.HasSuffix(s, "%")
+	s = strings.TrimSuffix(s, "%")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if pct {
+		return v / 100, nil
+	}
+	return v, nil
+}
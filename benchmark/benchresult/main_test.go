@@ -0,0 +1,73 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import "testing"
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "5%", want: 0.05},
+		{in: "5", want: 5},
+		{in: "0%", want: 0},
+		{in: "  10% ", want: 0.1},
+		{in: "not-a-number", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseThreshold(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseThreshold(%q) = %v, nil; want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseThreshold(%q) returned unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseThreshold(%q) = %v; want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsRegression(t *testing.T) {
+	tests := []struct {
+		name           string
+		baseUs, curUs  float64
+		thresholdRatio float64
+		wantRegressed  bool
+	}{
+		{name: "within threshold", baseUs: 100, curUs: 104, thresholdRatio: 0.05, wantRegressed: false},
+		{name: "beyond threshold", baseUs: 100, curUs: 106, thresholdRatio: 0.05, wantRegressed: true},
+		{name: "improvement never regresses", baseUs: 100, curUs: 50, thresholdRatio: 0.05, wantRegressed: false},
+		{name: "zero base is skipped", baseUs: 0, curUs: 1000, thresholdRatio: 0.05, wantRegressed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := isRegression(tt.baseUs, tt.curUs, tt.thresholdRatio)
+			if got != tt.wantRegressed {
+				t.Errorf("isRegression(%v, %v, %v) regressed = %v; want %v", tt.baseUs, tt.curUs, tt.thresholdRatio, got, tt.wantRegressed)
+			}
+		})
+	}
+}
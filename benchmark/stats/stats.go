@@ -0,0 +1,201 @@
+/*
+ *
+ * Copyright 2017 gRPC authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package stats tracks the per-permutation latency/throughput a benchmark
+// run accumulates, so benchmain can print it and benchresult can compare
+// two runs against each other.
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Features is the set of parameters that describe one permutation of a
+// benchmark sweep. Every flag that changes how calls are made or what they
+// measure needs a field here, rather than a side-channel global, so that
+// two permutations which differ only in that flag print and compare as
+// distinct rows instead of silently aliasing. The open-loop load settings
+// (-loadType, -targetQPS) are not fields here, so permutations that differ
+// only in -targetQPS print with identical Features.
+type Features struct {
+	NetworkMode        string
+	EnableTrace        bool
+	Latency            time.Duration
+	Kbps               int
+	Mtu                int
+	MaxConcurrentCalls int
+	ReqSizeBytes       int
+	RespSizeBytes      int
+	ModeCompressor     string
+	EnableChannelz     bool
+	EnablePreloader    bool
+	// Security is the -security mode (none/tls/alts) the permutation ran
+	// under.
+	Security string
+}
+
+// String returns a one-line summary of f, used both as the Features column
+// benchresult prints and as the jsonResult.Features string benchmain
+// writes under -resultFormat=json.
+func (f Features) String() string {
+	s := fmt.Sprintf("networkMode_%s-trace_%t-latency_%s-kbps_%d-MTU_%d-maxConcurrentCalls_%d-reqSize_%dB-respSize_%dB-Compressor_%s-channelz_%t-preloader_%t",
+		f.NetworkMode, f.EnableTrace, f.Latency, f.Kbps, f.Mtu, f.MaxConcurrentCalls, f.ReqSizeBytes, f.RespSizeBytes, f.ModeCompressor, f.EnableChannelz, f.EnablePreloader)
+	if f.Security != "" && f.Security != "none" {
+		s += fmt.Sprintf("-security_%s", f.Security)
+	}
+	return s
+}
+
+type durationSlice []time.Duration
+
+func (d durationSlice) Len() int           { return len(d) }
+func (d durationSlice) Less(i, j int) bool { return d[i] < d[j] }
+func (d durationSlice) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+// Stats accumulates per-call latencies for one benchmark permutation
+// between a SetBenchmarkResult/Clear pair, and formats them the way
+// benchmain prints after each run. It is not safe for concurrent Add
+// calls; callers that record from multiple goroutines (e.g.
+// runOpenLoopBenchmark) serialize their own Add calls with a mutex.
+type Stats struct {
+	numBuckets int
+	durations  durationSlice
+	sorted     bool
+
+	result BenchResults
+}
+
+// NewStats returns a Stats that stores numBuckets (16 if numBuckets isn't
+// positive). String does not use it: it prints only fixed percentiles, not a
+// bucketed histogram.
+func NewStats(numBuckets int) *Stats {
+	if numBuckets <= 0 {
+		numBuckets = 16
+	}
+	return &Stats{numBuckets: numBuckets}
+}
+
+// Add records one RPC's elapsed time.
+func (s *Stats) Add(d time.Duration) {
+	s.durations = append(s.durations, d)
+	s.sorted = false
+}
+
+// Clear discards every Add call since the run started, so s can be reused
+// for the next permutation.
+func (s *Stats) Clear() {
+	s.durations = s.durations[:0]
+	s.sorted = false
+}
+
+// SortLatency sorts the recorded durations in place. String and
+// SetBenchmarkResult call it themselves, so callers need not.
+func (s *Stats) SortLatency() {
+	if !s.sorted {
+		sort.Sort(s.durations)
+		s.sorted = true
+	}
+}
+
+func (s *Stats) percentile(p float64) time.Duration {
+	s.SortLatency()
+	if len(s.durations) == 0 {
+		return 0
+	}
+	idx := int(float64(len(s.durations)-1) * p)
+	return s.durations[idx]
+}
+
+// SetBenchmarkResult records name/features/count/allocation counters for
+// the durations s has accumulated since the last Clear. sharedPos marks
+// which Features fields are constant across the whole sweep, so
+// benchresult's printer can omit them from the diff.
+func (s *Stats) SetBenchmarkResult(name string, features Features, count int, allocedBytesPerOp, allocsPerOp int64, sharedPos []bool) {
+	s.SortLatency()
+	s.result = BenchResults{
+		Name:              name,
+		Features:          features,
+		Count:             count,
+		AllocedBytesPerOp: allocedBytesPerOp,
+		AllocsPerOp:       allocsPerOp,
+		SharedPos:         sharedPos,
+		P50:               s.percentile(0.5),
+		P90:               s.percentile(0.9),
+		P99:               s.percentile(0.99),
+	}
+}
+
+// GetBenchmarkResults returns the result recorded by the last
+// SetBenchmarkResult call, ready for gob-encoding to -resultFile.
+func (s *Stats) GetBenchmarkResults() BenchResults {
+	return s.result
+}
+
+// BenchString prints the one-line go-test-style benchmark result from the
+// last SetBenchmarkResult call, including the security mode the run used
+// so two permutations that only differ by -security don't print as
+// identical rows.
+func (s *Stats) BenchString() string {
+	r := s.result
+	name := r.Name
+	if r.Features.Security != "" && r.Features.Security != "none" {
+		name += "-" + r.Features.Security
+	}
+	return fmt.Sprintf("Benchmark%s-%s\t%d\t%d ns/op", name, r.Features, r.Count, r.P50.Nanoseconds())
+}
+
+// String prints the 50th, 90th and 99th percentile latencies s has
+// accumulated since the last Clear.
+func (s *Stats) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%-12s%12s\n", "Percentile", "Latency")
+	for _, p := range []float64{50, 90, 99} {
+		fmt.Fprintf(&b, "%-12.0f%12s\n", p, s.percentile(p/100))
+	}
+	return b.String()
+}
+
+// BenchResults is one gob-encoded row of -resultFile: the
+// aggregated result of a single name/Features permutation, used by
+// benchresult to print a run standalone or diff it against a base run.
+type BenchResults struct {
+	Name              string
+	Features          Features
+	Count             int
+	AllocedBytesPerOp int64
+	AllocsPerOp       int64
+	SharedPos         []bool
+	P50, P90, P99     time.Duration
+}
+
+// String prints one BenchResults row standalone (`benchresult curPerf`).
+func (r BenchResults) String() string {
+	name := r.Name
+	if r.Features.Security != "" && r.Features.Security != "none" {
+		name += "-" + r.Features.Security
+	}
+	return fmt.Sprintf("%-40s %-80s %8d %10s %10s %10s", name, r.Features, r.Count, r.P50, r.P90, r.P99)
+}
+
+// DiffString prints r relative to base (`benchresult basePerf curPerf`).
+func (r BenchResults) DiffString(base BenchResults) string {
+	return fmt.Sprintf("%s\n  vs base: %8d %10s %10s %10s", r.String(), base.Count, base.P50, base.P90, base.P99)
+}